@@ -0,0 +1,226 @@
+// Package bench 提供基于 httputil 请求构造能力的压测工具,用于对单个接口做并发性能测试
+package bench
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qit-team/snow-core/utils/httputil"
+)
+
+// RequestFactory 每次调用生成一个待发送的请求,用于复用 httputil 已有的请求构造函数
+type RequestFactory func(ctx context.Context) (*http.Request, error)
+
+// Config 描述一次压测的参数
+type Config struct {
+	Request     RequestFactory  // 必填,请求构造函数
+	Client      httputil.Client // 可选,默认使用 30s 超时的 httputil.NewClient
+	Concurrency int             // 并发 worker 数,默认 1
+	Duration    time.Duration   // 固定时长模式,与 Count 二选一
+	Count       int             // 固定次数模式,优先级高于 Duration
+	RatePerSec  float64         // 全局 QPS 限制,<=0 表示不限速
+	WarmUp      time.Duration   // 预热时长,预热期间的请求不计入统计
+}
+
+// Result 是一次压测的汇总结果
+type Result struct {
+	Total       int64
+	Errors      int64
+	Elapsed     time.Duration
+	RPS         float64
+	ErrorRate   float64
+	P50         time.Duration
+	P90         time.Duration
+	P99         time.Duration
+	StatusCodes map[int]int64
+}
+
+// Run 按 Config 执行压测,支持固定次数(Count>0)或固定时长(Duration>0)两种模式;二者必须设置一个,
+// 否则在一个不会被取消的 ctx 下 worker 会无限跑下去
+func Run(ctx context.Context, cfg Config) (*Result, error) {
+	if cfg.Count <= 0 && cfg.Duration <= 0 {
+		return nil, errors.New("bench: Config.Count or Config.Duration must be set")
+	}
+
+	concurrency := cfg.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	client := cfg.Client
+	if client == nil {
+		client = httputil.NewClient(httputil.WithTimeout(30 * time.Second))
+	}
+
+	limiter := newRateLimiter(cfg.RatePerSec)
+	collector := newStatsCollector()
+
+	if cfg.WarmUp > 0 {
+		warmUpCtx, cancel := context.WithTimeout(ctx, cfg.WarmUp)
+		runWorkers(warmUpCtx, concurrency, client, cfg.Request, limiter, newStatsCollector(), 0)
+		cancel()
+	}
+
+	runCtx := ctx
+	var cancel context.CancelFunc
+	if cfg.Count <= 0 && cfg.Duration > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	start := time.Now()
+	runWorkers(runCtx, concurrency, client, cfg.Request, limiter, collector, cfg.Count)
+	elapsed := time.Since(start)
+
+	return collector.result(elapsed), nil
+}
+
+// runWorkers 启动 concurrency 个 worker 并等待全部结束;count>0 时按总次数瓜分任务,否则跑到 ctx 结束
+func runWorkers(ctx context.Context, concurrency int, client httputil.Client, factory RequestFactory, limiter *rateLimiter, collector *statsCollector, count int) {
+	var wg sync.WaitGroup
+	var remaining int64 = int64(count)
+	useCount := count > 0
+
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				if useCount {
+					if atomic.AddInt64(&remaining, -1) < 0 {
+						return
+					}
+				}
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+				if limiter != nil {
+					if !limiter.wait(ctx) {
+						return
+					}
+				}
+				doOnce(ctx, client, factory, collector)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func doOnce(ctx context.Context, client httputil.Client, factory RequestFactory, collector *statsCollector) {
+	req, err := factory(ctx)
+	if err != nil {
+		collector.record(0, 0, err)
+		return
+	}
+	start := time.Now()
+	resp, err := client.Do(ctx, req)
+	latency := time.Since(start)
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+		resp.Body.Close()
+	}
+	collector.record(status, latency, err)
+}
+
+// statsCollector 汇总压测过程中的计数和延迟分布;延迟用 histogram 以 O(1) 原子操作记录,
+// 避免在每个请求上加锁并把样本追加进一个无上限增长的 slice 里
+type statsCollector struct {
+	total       int64
+	errors      int64
+	latencies   *histogram
+	statusMu    sync.Mutex
+	statusCodes map[int]int64
+}
+
+func newStatsCollector() *statsCollector {
+	return &statsCollector{
+		latencies:   newHistogram(),
+		statusCodes: make(map[int]int64),
+	}
+}
+
+func (s *statsCollector) record(status int, latency time.Duration, err error) {
+	atomic.AddInt64(&s.total, 1)
+	if err != nil {
+		atomic.AddInt64(&s.errors, 1)
+	}
+	s.latencies.record(latency)
+	if status != 0 {
+		s.statusMu.Lock()
+		s.statusCodes[status]++
+		s.statusMu.Unlock()
+	}
+}
+
+func (s *statsCollector) result(elapsed time.Duration) *Result {
+	total := atomic.LoadInt64(&s.total)
+	errs := atomic.LoadInt64(&s.errors)
+
+	s.statusMu.Lock()
+	statusCodes := make(map[int]int64, len(s.statusCodes))
+	for k, v := range s.statusCodes {
+		statusCodes[k] = v
+	}
+	s.statusMu.Unlock()
+
+	r := &Result{
+		Total:       total,
+		Errors:      errs,
+		Elapsed:     elapsed,
+		StatusCodes: statusCodes,
+		P50:         s.latencies.percentile(0.50),
+		P90:         s.latencies.percentile(0.90),
+		P99:         s.latencies.percentile(0.99),
+	}
+	if elapsed > 0 {
+		r.RPS = float64(total) / elapsed.Seconds()
+	}
+	if total > 0 {
+		r.ErrorRate = float64(errs) / float64(total)
+	}
+	return r
+}
+
+// rateLimiter 是一个简单的令牌桶限速器,用于控制压测的全局 QPS
+type rateLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time
+}
+
+func newRateLimiter(ratePerSec float64) *rateLimiter {
+	if ratePerSec <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / ratePerSec)}
+}
+
+// wait 阻塞直到分配到下一个令牌,ctx 结束时返回 false
+func (rl *rateLimiter) wait(ctx context.Context) bool {
+	rl.mu.Lock()
+	now := time.Now()
+	if rl.next.Before(now) {
+		rl.next = now
+	}
+	wait := rl.next.Sub(now)
+	rl.next = rl.next.Add(rl.interval)
+	rl.mu.Unlock()
+
+	if wait <= 0 {
+		return true
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,62 @@
+package bench
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRun_RequiresCountOrDuration(t *testing.T) {
+	_, err := Run(context.Background(), Config{
+		Request: func(ctx context.Context) (*http.Request, error) {
+			return http.NewRequest("GET", "http://example.invalid", nil)
+		},
+	})
+	if err == nil {
+		t.Fatal("expected an error when neither Count nor Duration is set")
+	}
+}
+
+func TestRun_FixedCount(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	result, err := Run(context.Background(), Config{
+		Request: func(ctx context.Context) (*http.Request, error) {
+			return http.NewRequest("GET", srv.URL, nil)
+		},
+		Concurrency: 4,
+		Count:       40,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Total != 40 {
+		t.Fatalf("expected 40 total requests, got %d", result.Total)
+	}
+	if result.Errors != 0 {
+		t.Fatalf("expected no errors, got %d", result.Errors)
+	}
+	if result.StatusCodes[http.StatusOK] != 40 {
+		t.Fatalf("expected 40 status-200 entries, got %d", result.StatusCodes[http.StatusOK])
+	}
+}
+
+func TestHistogram_Percentiles(t *testing.T) {
+	h := newHistogram()
+	for i := 1; i <= 100; i++ {
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+	p50 := h.percentile(0.50)
+	p99 := h.percentile(0.99)
+	if p50 < 40*time.Millisecond || p50 > 60*time.Millisecond {
+		t.Errorf("p50 = %s, want roughly 50ms", p50)
+	}
+	if p99 < 95*time.Millisecond || p99 > 105*time.Millisecond {
+		t.Errorf("p99 = %s, want roughly 99-100ms", p99)
+	}
+}
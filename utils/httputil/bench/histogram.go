@@ -0,0 +1,73 @@
+package bench
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// subBucketsPerOctave 决定每个 2 倍区间(octave)内线性细分的桶数,值越大分位数估计越精确
+// 128 对应约 0.5% 的相对误差,足够压测场景使用
+const subBucketsPerOctave = 128
+
+// maxTrackedNanos 是直方图能记录的最大延迟,超出的样本会被计入最后一个桶(即按 >= maxTrackedNanos 统计)
+const maxTrackedNanos = float64(time.Hour)
+
+// histogram 是一个简化版的 HDR 风格直方图:用固定数量的对数分布桶以 O(1) 的原子自增记录延迟样本,
+// 避免了"每个请求都加锁、把样本存进一个无上限的 slice 里再整体排序"的写法在高并发/长时间压测下的锁竞争和内存增长
+type histogram struct {
+	total  int64
+	counts []int64
+}
+
+func newHistogram() *histogram {
+	numBuckets := int(math.Log2(maxTrackedNanos)*subBucketsPerOctave) + 1
+	return &histogram{counts: make([]int64, numBuckets)}
+}
+
+// record 记录一次延迟样本,全程只有针对单个桶的原子自增,多个 worker 并发调用不会相互阻塞
+func (h *histogram) record(d time.Duration) {
+	idx := bucketIndex(d)
+	if idx >= len(h.counts) {
+		idx = len(h.counts) - 1
+	}
+	atomic.AddInt64(&h.counts[idx], 1)
+	atomic.AddInt64(&h.total, 1)
+}
+
+// percentile 返回分位数 p(0~1)对应的近似延迟,精度取决于 subBucketsPerOctave
+func (h *histogram) percentile(p float64) time.Duration {
+	total := atomic.LoadInt64(&h.total)
+	if total == 0 {
+		return 0
+	}
+	target := int64(math.Ceil(p * float64(total)))
+	if target < 1 {
+		target = 1
+	}
+	var cum int64
+	for i := range h.counts {
+		cum += atomic.LoadInt64(&h.counts[i])
+		if cum >= target {
+			return bucketValue(i)
+		}
+	}
+	return bucketValue(len(h.counts) - 1)
+}
+
+func bucketIndex(d time.Duration) int {
+	ns := float64(d)
+	if ns < 1 {
+		ns = 1
+	}
+	idx := int(math.Log2(ns) * subBucketsPerOctave)
+	if idx < 0 {
+		idx = 0
+	}
+	return idx
+}
+
+func bucketValue(idx int) time.Duration {
+	exp := float64(idx) / subBucketsPerOctave
+	return time.Duration(math.Pow(2, exp))
+}
@@ -0,0 +1,350 @@
+package httputil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/qit-team/snow-core/utils"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v2"
+)
+
+// RetryPredicate 判断一次请求是否需要重试
+type RetryPredicate func(resp *http.Response, err error) bool
+
+// BackoffFunc 根据重试次数(从0开始)返回需要等待的时长
+type BackoffFunc func(attempt int) time.Duration
+
+// RequestBuilder 链式构造并发送 http 请求
+// 用法: httputil.NewRequestBuilder(ctx).Method("POST").URL(u).JSON(body).Retry(3, backoff).Do()
+type RequestBuilder struct {
+	ctx         context.Context
+	client      Client
+	method      string
+	rawUrl      string
+	query       map[string]interface{}
+	headers     map[string]string
+	bodyBytes   []byte // 统一缓冲,保证重试时可回退
+	contentType string
+	timeout     time.Duration // 单次请求超时
+	maxAttempts int
+	backoff     BackoffFunc
+	retryIf     RetryPredicate
+	err         error
+}
+
+// NewRequestBuilder 创建一个请求构造器,默认 GET、30s 超时、不重试
+func NewRequestBuilder(ctx context.Context) *RequestBuilder {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &RequestBuilder{
+		ctx:         ctx,
+		method:      "GET",
+		headers:     make(map[string]string),
+		timeout:     30 * time.Second,
+		maxAttempts: 1,
+		retryIf:     DefaultRetryPredicate,
+	}
+}
+
+// Method 设置请求方法
+func (b *RequestBuilder) Method(method string) *RequestBuilder {
+	b.method = strings.ToUpper(method)
+	return b
+}
+
+// URL 设置请求地址
+func (b *RequestBuilder) URL(rawUrl string) *RequestBuilder {
+	b.rawUrl = rawUrl
+	return b
+}
+
+// Query 追加 querystring 参数
+func (b *RequestBuilder) Query(params map[string]interface{}) *RequestBuilder {
+	if b.query == nil {
+		b.query = make(map[string]interface{}, len(params))
+	}
+	for k, v := range params {
+		b.query[k] = v
+	}
+	return b
+}
+
+// Header 设置请求头,支持多次调用累加
+func (b *RequestBuilder) Header(key, value string) *RequestBuilder {
+	b.headers[key] = value
+	return b
+}
+
+// Headers 批量设置请求头
+func (b *RequestBuilder) Headers(headers map[string]string) *RequestBuilder {
+	for k, v := range headers {
+		b.headers[k] = v
+	}
+	return b
+}
+
+// Timeout 设置单次请求超时(不含重试耗时)
+func (b *RequestBuilder) Timeout(timeout time.Duration) *RequestBuilder {
+	b.timeout = timeout
+	return b
+}
+
+// JSON 将 v 编码为 JSON 并作为请求体
+func (b *RequestBuilder) JSON(v interface{}) *RequestBuilder {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.contentType = ContentTypeJSON
+	b.bodyBytes = buf
+	return b
+}
+
+// XML 将 v 编码为 XML 并作为请求体
+func (b *RequestBuilder) XML(v interface{}) *RequestBuilder {
+	buf, err := xml.Marshal(v)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.contentType = ContentTypeXML
+	b.bodyBytes = buf
+	return b
+}
+
+// YAML 将 v 编码为 YAML 并作为请求体
+func (b *RequestBuilder) YAML(v interface{}) *RequestBuilder {
+	buf, err := yaml.Marshal(v)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.contentType = ContentTypeYAML
+	b.bodyBytes = buf
+	return b
+}
+
+// Protobuf 将 msg 编码为 protobuf 并作为请求体
+func (b *RequestBuilder) Protobuf(msg proto.Message) *RequestBuilder {
+	buf, err := proto.Marshal(msg)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.contentType = ContentTypeProtobuf
+	b.bodyBytes = buf
+	return b
+}
+
+// Form 将 params 编码为 x-www-form-urlencoded 并作为请求体
+func (b *RequestBuilder) Form(params map[string]interface{}) *RequestBuilder {
+	b.contentType = ContentTypeForm
+	b.bodyBytes = []byte(utils.HttpBuildQuery(params))
+	return b
+}
+
+// Multipart 将 fields/files 编码为 multipart/form-data 并作为请求体,复用 NewMultipartRequest 的编码逻辑
+func (b *RequestBuilder) Multipart(fields map[string]interface{}, files map[string]FileSource) *RequestBuilder {
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+	if err := writeMultipartBody(mw, fields, files); err != nil {
+		b.err = err
+		return b
+	}
+	if err := mw.Close(); err != nil {
+		b.err = err
+		return b
+	}
+	b.contentType = mw.FormDataContentType()
+	b.bodyBytes = buf.Bytes()
+	return b
+}
+
+// Raw 直接使用 io.Reader 作为请求体;不指定 contentType 时,会用标准库的内容嗅探自动推断
+// body 会被整体读入内存一次,以便重试时回退
+func (b *RequestBuilder) Raw(body io.Reader, contentType string) *RequestBuilder {
+	if body == nil {
+		b.contentType = contentType
+		b.bodyBytes = nil
+		return b
+	}
+	buf, err := ioutil.ReadAll(body)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(buf)
+	}
+	b.contentType = contentType
+	b.bodyBytes = buf
+	return b
+}
+
+// Retry 开启重试,maxAttempts 为总尝试次数(含首次),backoff 为 nil 时使用默认指数退避+抖动
+func (b *RequestBuilder) Retry(maxAttempts int, backoff BackoffFunc) *RequestBuilder {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	b.maxAttempts = maxAttempts
+	if backoff == nil {
+		backoff = NewExponentialBackoff(100*time.Millisecond, 2*time.Second)
+	}
+	b.backoff = backoff
+	return b
+}
+
+// RetryIf 自定义重试判断条件,覆盖默认的 DefaultRetryPredicate
+func (b *RequestBuilder) RetryIf(predicate RetryPredicate) *RequestBuilder {
+	b.retryIf = predicate
+	return b
+}
+
+// UseClient 使用自定义 Client 而非内部默认创建的 Client
+func (b *RequestBuilder) UseClient(client Client) *RequestBuilder {
+	b.client = client
+	return b
+}
+
+// NewExponentialBackoff 返回 base * 2^attempt(封顶 max) 再加 0~base 随机抖动的退避函数
+func NewExponentialBackoff(base, max time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		d := base * time.Duration(int64(1)<<uint(attempt))
+		if d <= 0 || d > max {
+			d = max
+		}
+		jitter := time.Duration(rand.Int63n(int64(base) + 1))
+		return d + jitter
+	}
+}
+
+// DefaultRetryPredicate 默认仅对网络错误或 429/5xx 网关类状态码进行重试
+// 注意: myClient.Do 会把非 200 的响应也包装成非 nil 的 err,因此必须优先看 resp.StatusCode,
+// 否则任何非 200(包括 404/400 等不可重试的状态码)都会被 err != nil 误判为可重试
+func DefaultRetryPredicate(resp *http.Response, err error) bool {
+	if resp != nil {
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		default:
+			return false
+		}
+	}
+	// resp 为 nil 说明请求没有发出/没有收到响应,属于网络层错误,可以重试
+	return err != nil
+}
+
+// drainAndClose 排空并关闭一个即将被丢弃的响应体,使底层连接能够被 Transport 复用
+func drainAndClose(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "PUT", "DELETE", "OPTIONS":
+		return true
+	default:
+		return false
+	}
+}
+
+// build 构造一次 *http.Request,每次重试都重新构建以便回退 body
+func (b *RequestBuilder) build() (*http.Request, error) {
+	rawUrl := b.rawUrl
+	if len(b.query) > 0 {
+		paramStr := utils.HttpBuildQuery(b.query)
+		op := "?"
+		if strings.Contains(rawUrl, "?") {
+			op = "&"
+		}
+		rawUrl = utils.Join(rawUrl, op, paramStr)
+	}
+	if _, err := url.Parse(rawUrl); err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if b.bodyBytes != nil {
+		body = bytes.NewReader(b.bodyBytes)
+	}
+
+	req, err := http.NewRequest(b.method, rawUrl, body)
+	if err != nil {
+		return nil, err
+	}
+	if b.contentType != "" {
+		req.Header.Set("Content-Type", b.contentType)
+	}
+	for k, v := range b.headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// Do 执行请求,按配置重试,直到成功、不可重试或 ctx 结束
+func (b *RequestBuilder) Do() (resp *http.Response, err error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	client := b.client
+	if client == nil {
+		client = NewClient(WithTimeout(b.timeout))
+	}
+
+	attempts := b.maxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	canRetry := attempts > 1 && isIdempotent(b.method)
+
+	for attempt := 0; ; attempt++ {
+		var req *http.Request
+		req, err = b.build()
+		if err != nil {
+			return nil, err
+		}
+
+		ctx := b.ctx
+		var cancel context.CancelFunc
+		if b.timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		}
+		resp, err = client.Do(ctx, req)
+		if cancel != nil {
+			cancel()
+		}
+
+		if !canRetry || attempt == attempts-1 || !b.retryIf(resp, err) {
+			return resp, err
+		}
+
+		// 本次响应已决定丢弃并重试,排干并关闭 body 以便底层连接可以复用,否则会逐次泄漏连接
+		drainAndClose(resp)
+
+		wait := b.backoff(attempt)
+		select {
+		case <-time.After(wait):
+		case <-b.ctx.Done():
+			return resp, b.ctx.Err()
+		}
+	}
+}
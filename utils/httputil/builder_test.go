@@ -0,0 +1,183 @@
+package httputil
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"context"
+)
+
+func TestDefaultRetryPredicate(t *testing.T) {
+	cases := []struct {
+		name   string
+		resp   *http.Response
+		err    error
+		expect bool
+	}{
+		{"network error, no response", nil, io.ErrClosedPipe, true},
+		{"status 200 is never retryable", &http.Response{StatusCode: http.StatusOK}, nil, false},
+		{"status 404 is not retryable", &http.Response{StatusCode: http.StatusNotFound}, nil, false},
+		{"status 400 is not retryable", &http.Response{StatusCode: http.StatusBadRequest}, nil, false},
+		{"status 429 is retryable", &http.Response{StatusCode: http.StatusTooManyRequests}, nil, true},
+		{"status 503 is retryable", &http.Response{StatusCode: http.StatusServiceUnavailable}, nil, true},
+		// myClient.Do 会把非 200 的响应包装成非 nil 的 err,即便如此,只要 resp 不为 nil 也必须以状态码为准
+		{"non-retryable status wrapped with non-nil err stays non-retryable", &http.Response{StatusCode: http.StatusNotFound}, io.ErrUnexpectedEOF, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := DefaultRetryPredicate(c.resp, c.err); got != c.expect {
+				t.Errorf("DefaultRetryPredicate(%v, %v) = %v, want %v", c.resp, c.err, got, c.expect)
+			}
+		})
+	}
+}
+
+// closeTrackingBody 记录自己是否被关闭,用于验证重试时丢弃的响应体有没有被释放
+type closeTrackingBody struct {
+	io.Reader
+	closed *int32
+}
+
+func (b closeTrackingBody) Close() error {
+	atomic.AddInt32(b.closed, 1)
+	return nil
+}
+
+// trackingTransport 包裹一个真实的 RoundTripper,记录每个响应体各自是否被关闭
+type trackingTransport struct {
+	inner  http.RoundTripper
+	closed []*int32
+}
+
+func (t *trackingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.inner.RoundTrip(req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	var n int32
+	resp.Body = closeTrackingBody{Reader: resp.Body, closed: &n}
+	t.closed = append(t.closed, &n)
+	return resp, nil
+}
+
+func TestRequestBuilderRetry_ClosesDiscardedBodies(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&attempts, 1)
+		if n < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	tracker := &trackingTransport{inner: http.DefaultTransport}
+	cli := &myClient{cli: &http.Client{Transport: tracker}}
+
+	resp, err := NewRequestBuilder(context.Background()).
+		Method("GET").
+		URL(srv.URL).
+		Retry(5, func(attempt int) time.Duration { return time.Millisecond }).
+		UseClient(cli).
+		Do()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected final status 200, got %d", resp.StatusCode)
+	}
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+	if len(tracker.closed) != 3 {
+		t.Fatalf("expected 3 tracked responses, got %d", len(tracker.closed))
+	}
+	// 前两次(被丢弃重试的)响应体必须被关闭,避免连接泄漏;最后一次由调用方自己读取/关闭
+	for i, n := range tracker.closed {
+		if atomic.LoadInt32(n) != 1 {
+			t.Errorf("response body for attempt %d was not closed exactly once (closed=%d)", i+1, atomic.LoadInt32(n))
+		}
+	}
+}
+
+func TestRequestBuilder_BodyEncodingsSetContentType(t *testing.T) {
+	type payload struct {
+		Name string `json:"name" xml:"name" yaml:"name"`
+	}
+
+	t.Run("XML", func(t *testing.T) {
+		b := NewRequestBuilder(context.Background()).XML(payload{Name: "a"})
+		if b.contentType != ContentTypeXML {
+			t.Fatalf("contentType = %q, want %q", b.contentType, ContentTypeXML)
+		}
+		if !bytesContain(b.bodyBytes, "<name>a</name>") {
+			t.Fatalf("body = %q, want it to contain XML-encoded payload", b.bodyBytes)
+		}
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		b := NewRequestBuilder(context.Background()).YAML(payload{Name: "a"})
+		if b.contentType != ContentTypeYAML {
+			t.Fatalf("contentType = %q, want %q", b.contentType, ContentTypeYAML)
+		}
+		if !bytesContain(b.bodyBytes, "name: a") {
+			t.Fatalf("body = %q, want it to contain YAML-encoded payload", b.bodyBytes)
+		}
+	})
+
+	t.Run("Multipart", func(t *testing.T) {
+		b := NewRequestBuilder(context.Background()).Multipart(map[string]interface{}{"name": "a"}, nil)
+		if !strings.HasPrefix(b.contentType, "multipart/form-data; boundary=") {
+			t.Fatalf("contentType = %q, want a multipart/form-data boundary", b.contentType)
+		}
+		if !bytesContain(b.bodyBytes, `name="name"`) {
+			t.Fatalf("body = %q, want it to contain the encoded field", b.bodyBytes)
+		}
+	})
+
+	t.Run("Raw infers content-type when unset", func(t *testing.T) {
+		b := NewRequestBuilder(context.Background()).Raw(strings.NewReader("<html><body>hi</body></html>"), "")
+		if !strings.HasPrefix(b.contentType, "text/html") {
+			t.Fatalf("contentType = %q, want it to be sniffed as text/html", b.contentType)
+		}
+	})
+}
+
+func bytesContain(b []byte, substr string) bool {
+	return strings.Contains(string(b), substr)
+}
+
+func TestRequestBuilderRetry_NonRetryableStatusStopsAtFirstAttempt(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	resp, err := NewRequestBuilder(context.Background()).
+		Method("GET").
+		URL(srv.URL).
+		Retry(3, func(attempt int) time.Duration { return 0 }).
+		Do()
+	if resp != nil {
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if err == nil {
+		t.Fatalf("expected an error for 404 response")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Fatalf("expected exactly 1 attempt for a non-retryable 404, got %d", got)
+	}
+}
@@ -0,0 +1,214 @@
+package httputil
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	stdhttputil "net/http/httputil"
+	"strings"
+)
+
+// defaultRedactHeaders 是 WithDebug 默认脱敏的请求头,避免密钥/会话信息被打印到日志
+var defaultRedactHeaders = []string{"Authorization", "Cookie"}
+
+// ToCurl 把一个 *http.Request 还原成等价的 curl 命令,便于复现排查问题
+// 请求体会被整体读出再写回 req.Body(不论是否设置了 GetBody),以免影响后续真正发送
+func ToCurl(req *http.Request) (string, error) {
+	return toCurl(req, nil)
+}
+
+func toCurl(req *http.Request, redact []string) (string, error) {
+	parts := []string{"curl", "-X", shellQuote(req.Method)}
+
+	for k, values := range req.Header {
+		for _, v := range values {
+			if isRedacted(k, redact) {
+				v = "***"
+			}
+			parts = append(parts, "-H", shellQuote(fmt.Sprintf("%s: %s", k, v)))
+		}
+	}
+
+	contentType := req.Header.Get("Content-Type")
+	body, err := peekBody(req)
+	if err != nil {
+		return "", err
+	}
+
+	if len(body) > 0 && strings.HasPrefix(contentType, "multipart/") {
+		if formFlags, ferr := multipartToCurlFlags(contentType, body); ferr == nil {
+			parts = append(parts, formFlags...)
+		} else {
+			parts = append(parts, "--data-binary", shellQuote("<multipart body, "+ferr.Error()+">"))
+		}
+	} else if len(body) > 0 {
+		parts = append(parts, "--data-binary", shellQuote(string(body)))
+	}
+
+	parts = append(parts, shellQuote(req.URL.String()))
+	return strings.Join(parts, " "), nil
+}
+
+// peekBody 读出请求体并把它重新塞回 req.Body,保证调用方后续仍能正常发送请求
+func peekBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// multipartToCurlFlags 把 multipart/form-data 请求体转换为一组 curl -F 参数
+// 文件分片只能还原字段名和文件名(用 @filename 引用),无法把原始字节内联进 curl 命令
+func multipartToCurlFlags(contentType string, body []byte) ([]string, error) {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, err
+	}
+	boundary, ok := params["boundary"]
+	if !ok {
+		return nil, fmt.Errorf("missing boundary")
+	}
+
+	var flags []string
+	mr := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		name := part.FormName()
+		if fileName := part.FileName(); fileName != "" {
+			flags = append(flags, "-F", shellQuote(fmt.Sprintf("%s=@%s", name, fileName)))
+		} else {
+			val, _ := ioutil.ReadAll(part)
+			flags = append(flags, "-F", shellQuote(fmt.Sprintf("%s=%s", name, string(val))))
+		}
+		part.Close()
+	}
+	return flags, nil
+}
+
+func isRedacted(header string, redact []string) bool {
+	for _, r := range redact {
+		if strings.EqualFold(header, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// shellQuote 对 POSIX shell 做单引号转义,保证任意内容都能安全地出现在命令行中
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// DumpRequest 输出请求的原始报文(请求行、headers、可选 body),二进制 body 以十六进制摘要展示
+// req.Clone 不会深拷贝 Body(克隆体和原请求共享同一个 io.ReadCloser),而 DumpRequestOut 在
+// body=true 时会读空并关闭它,所以这里必须先把 body 缓冲成字节,分别为原始 req 和克隆体各配一个
+// 独立的 reader,否则 dump 完之后调用方的 req.Body 会被读成空的
+func DumpRequest(req *http.Request, body bool) (string, error) {
+	buf, err := peekBody(req)
+	if err != nil {
+		return "", err
+	}
+	clone := req.Clone(req.Context())
+	if buf != nil {
+		clone.Body = ioutil.NopCloser(bytes.NewReader(buf))
+	}
+	dump, err := stdhttputil.DumpRequestOut(clone, body)
+	if err != nil {
+		return "", err
+	}
+	return summarizeBinary(dump), nil
+}
+
+// DumpResponse 输出响应的原始报文(状态行、headers、可选 body),二进制 body 以十六进制摘要展示
+func DumpResponse(resp *http.Response, body bool) (string, error) {
+	dump, err := stdhttputil.DumpResponse(resp, body)
+	if err != nil {
+		return "", err
+	}
+	return summarizeBinary(dump), nil
+}
+
+// summarizeBinary 若报文正文不是可打印文本,则将其替换为十六进制摘要,避免污染终端输出
+func summarizeBinary(dump []byte) string {
+	sep := []byte("\r\n\r\n")
+	idx := bytes.Index(dump, sep)
+	if idx == -1 {
+		return string(dump)
+	}
+	header := dump[:idx+len(sep)]
+	payload := dump[idx+len(sep):]
+	if len(payload) == 0 || isPrintable(payload) {
+		return string(dump)
+	}
+	return string(header) + fmt.Sprintf("<binary %d bytes, hex: %x>", len(payload), truncate(payload, 64))
+}
+
+func isPrintable(b []byte) bool {
+	for _, c := range b {
+		if c == '\n' || c == '\r' || c == '\t' {
+			continue
+		}
+		if c < 0x20 || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+func truncate(b []byte, n int) []byte {
+	if len(b) > n {
+		return b[:n]
+	}
+	return b
+}
+
+// WithDebug 开启调试模式:每次 Do 都会打印等价 curl 命令和原始响应报文,便于排查问题
+func WithDebug(logger Logger) Option {
+	if logger == nil {
+		logger = DefaultLogger
+	}
+	return func(c *myClient) {
+		c.debug = true
+		c.logger = logger
+	}
+}
+
+// WithRedact 指定 WithDebug 打印 curl 命令时需要脱敏的请求头,默认脱敏 Authorization 和 Cookie
+func WithRedact(headers []string) Option {
+	return func(c *myClient) {
+		c.redactHeaders = headers
+	}
+}
+
+// debugMiddleware 打印请求对应的 curl 命令和原始响应报文,注册为链路中最靠近实际发送的中间件
+func debugMiddleware(c *myClient) Middleware {
+	return func(ctx context.Context, req *http.Request, next Handler) (*http.Response, error) {
+		redact := c.redactHeaders
+		if redact == nil {
+			redact = defaultRedactHeaders
+		}
+		if curl, err := toCurl(req, redact); err == nil {
+			c.logger.Printf("httputil debug: %s", curl)
+		}
+
+		resp, err := next.Do(ctx, req)
+		if resp != nil {
+			if dump, derr := DumpResponse(resp, true); derr == nil {
+				c.logger.Printf("httputil debug response:\n%s", dump)
+			}
+		}
+		return resp, err
+	}
+}
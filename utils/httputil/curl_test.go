@@ -0,0 +1,29 @@
+package httputil
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDumpRequest_DoesNotConsumeOriginalBody(t *testing.T) {
+	req, err := http.NewRequest("POST", "http://example.invalid", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := DumpRequest(req, true); err != nil {
+		t.Fatalf("DumpRequest: %v", err)
+	}
+
+	// req.Clone 不会深拷贝 Body,若 DumpRequest 直接把克隆体丢给 DumpRequestOut(body=true)
+	// 读空,原始 req.Body 会被一并读空,导致调用方后续真正发送请求时发出空 body
+	got, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatalf("unexpected error reading original body: %v", err)
+	}
+	if string(got) != "payload" {
+		t.Fatalf("req.Body after DumpRequest = %q, want %q", got, "payload")
+	}
+}
@@ -2,9 +2,12 @@ package httputil
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"github.com/qit-team/snow-core/utils"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 	"fmt"
@@ -17,7 +20,27 @@ const (
 )
 
 type myClient struct {
-	cli *http.Client
+	cli           *http.Client
+	middlewares   []Middleware
+	debug         bool
+	logger        Logger
+	redactHeaders []string
+
+	timeout time.Duration
+
+	// 传输层配置,见 transport.go 中的 With* Option
+	transport           *http.Transport
+	proxyURL            *url.URL
+	tlsConfig           *tls.Config
+	clientCerts         []tls.Certificate
+	rootCAs             *x509.CertPool
+	insecureSkipVerify  bool
+	cookieJar           http.CookieJar
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+	disableKeepAlives   bool
+
+	err error
 }
 
 type Client interface {
@@ -25,17 +48,39 @@ type Client interface {
 	Do(ctx context.Context, req *http.Request) (*http.Response, error)
 }
 
-// NewClient 创建 Client 实例
-func NewClient(timeout time.Duration) Client {
-	return &myClient{
-		cli: &http.Client{
-			Timeout: timeout,
-		},
+// NewClient 创建 Client 实例,通过 Option 配置超时、中间件、TLS/代理等能力
+// 未指定超时时默认 30s;旧版 NewClient(timeout) 的调用方式已不再支持,请改用 WithTimeout(timeout)
+func NewClient(opts ...Option) Client {
+	c := &myClient{timeout: 30 * time.Second}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.debug {
+		c.middlewares = append(c.middlewares, debugMiddleware(c))
+	}
+
+	transport, err := buildTransport(c)
+	if err != nil && c.err == nil {
+		c.err = err
+	}
+	c.cli = &http.Client{
+		Timeout:   c.timeout,
+		Transport: transport,
+		Jar:       c.cookieJar,
 	}
+	return c
 }
 
-//发送请求
+//发送请求,依次经过挂载的中间件后落到实际的 http.Client.Do
 func (c *myClient) Do(ctx context.Context, req *http.Request) (resp *http.Response, err error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	handler := chain(c.middlewares, c.doRaw)
+	return handler.Do(ctx, req)
+}
+
+func (c *myClient) doRaw(ctx context.Context, req *http.Request) (resp *http.Response, err error) {
 	req = req.WithContext(ctx)
 	resp, err = c.cli.Do(req)
 	httpCode := http.StatusOK
@@ -130,48 +175,61 @@ func NewJsonPostRequest(url string, params map[string]interface{}, args ...inter
 	return
 }
 
+// Get 发起 GET 请求,内部基于 RequestBuilder 实现,保留原有签名兼容旧调用
 func Get(ctx context.Context, url string, params map[string]interface{}, args ...interface{}) (resp *http.Response, err error) {
-	timeout := getTimeout(args...)
-	client := NewClient(timeout)
-	req, err := NewGetRequest(url, params, args...)
-	if err != nil {
-		return
+	b := NewRequestBuilder(ctx).Method("GET").URL(url).Query(params).Timeout(getTimeout(args...))
+	if len(args) > 0 {
+		applyHeaders(b, args[0])
 	}
-	resp, err = client.Do(ctx, req)
-	return
+	return b.Do()
 }
 
+// Post 发起表单 POST 请求,内部基于 RequestBuilder 实现,保留原有签名兼容旧调用
 func Post(ctx context.Context, url string, params map[string]interface{}, args ...interface{}) (resp *http.Response, err error) {
-	timeout := getTimeout(args...)
-	client := NewClient(timeout)
-	req, err := NewFormPostRequest(url, params, args...)
-	if err != nil {
-		return
+	b := NewRequestBuilder(ctx).Method("POST").URL(url).Form(params).Timeout(getTimeout(args...))
+	if len(args) > 0 {
+		applyHeaders(b, args[0])
 	}
-	resp, err = client.Do(ctx, req)
-	return
+	return b.Do()
 }
 
+// PostJson 发起 JSON POST 请求,内部基于 RequestBuilder 实现,保留原有签名兼容旧调用
 func PostJson(ctx context.Context, url string, params map[string]interface{}, args ...interface{}) (resp *http.Response, err error) {
-	timeout := getTimeout(args...)
-	client := NewClient(timeout)
-	req, err := NewJsonPostRequest(url, params, args...)
-	if err != nil {
-		return
+	b := NewRequestBuilder(ctx).Method("POST").URL(url).JSON(params).Timeout(getTimeout(args...))
+	if len(args) > 0 {
+		applyHeaders(b, args[0])
+	}
+	return b.Do()
+}
+
+// applyHeaders 将 Get/Post/PostJson 的可选 headers 参数应用到 RequestBuilder
+func applyHeaders(b *RequestBuilder, headers interface{}) {
+	switch hs := headers.(type) {
+	case map[string]string:
+		b.Headers(hs)
+	case []string:
+		for _, v := range hs {
+			strArr := strings.SplitN(v, ":", 2)
+			if len(strArr) >= 2 {
+				b.Header(strArr[0], strings.Trim(strArr[1], " "))
+			}
+		}
 	}
-	resp, err = client.Do(ctx, req)
-	return
 }
 
 func Request(ctx context.Context, method string, url string, params map[string]interface{}, args ...interface{}) (resp *http.Response, err error) {
 	timeout := getTimeout(args...)
-	client := NewClient(timeout)
+	client := NewClient(WithTimeout(timeout))
 	var req *http.Request
-	if strings.ToUpper(method) == "POST" {
+	switch strings.ToUpper(method) {
+	case "POST":
 		req, err = NewFormPostRequest(url, params, args...)
-	} else if strings.ToUpper(method) == "POST/JSON" {
+	case "POST/JSON":
 		req, err = NewJsonPostRequest(url, params, args...)
-	} else {
+	case "POST/MULTIPART":
+		files, _ := getOptions(args...)["files"].(map[string]FileSource)
+		req, err = NewMultipartRequest(url, params, files, args...)
+	default:
 		req, err = NewGetRequest(url, params, args...)
 	}
 	if err != nil {
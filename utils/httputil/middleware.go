@@ -0,0 +1,216 @@
+package httputil
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Handler 是中间件链中下一环节的抽象,最终会落到 myClient 内部真正发请求的那一环
+type Handler interface {
+	Do(ctx context.Context, req *http.Request) (*http.Response, error)
+}
+
+// HandlerFunc 是 Handler 的函数适配器
+type HandlerFunc func(ctx context.Context, req *http.Request) (*http.Response, error)
+
+func (f HandlerFunc) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return f(ctx, req)
+}
+
+// Middleware 以洋葱模型包裹请求链路,next 是下一个 Handler(可能是下一个中间件,也可能是最终的发送逻辑)
+type Middleware func(ctx context.Context, req *http.Request, next Handler) (*http.Response, error)
+
+// chain 把多个 Middleware 串成一个 Handler,按声明顺序从外到内依次执行
+func chain(mws []Middleware, final HandlerFunc) Handler {
+	h := Handler(final)
+	for i := len(mws) - 1; i >= 0; i-- {
+		mw := mws[i]
+		next := h
+		h = HandlerFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			return mw(ctx, req, next)
+		})
+	}
+	return h
+}
+
+// Option 配置 NewClient 创建出来的 Client
+type Option func(*myClient)
+
+// WithMiddleware 追加一个或多个中间件,按传入顺序从外到内包裹
+func WithMiddleware(mws ...Middleware) Option {
+	return func(c *myClient) {
+		c.middlewares = append(c.middlewares, mws...)
+	}
+}
+
+// Logger 是日志中间件依赖的最小接口,方便接入调用方自己的日志实现
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+type stdLogger struct{}
+
+func (stdLogger) Printf(format string, args ...interface{}) {
+	log.Printf(format, args...)
+}
+
+// DefaultLogger 是未指定 Logger 时使用的标准库实现
+var DefaultLogger Logger = stdLogger{}
+
+// LoggingMiddleware 记录请求方法、URL、耗时、状态码及错误信息
+func LoggingMiddleware(logger Logger) Middleware {
+	if logger == nil {
+		logger = DefaultLogger
+	}
+	return func(ctx context.Context, req *http.Request, next Handler) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next.Do(ctx, req)
+		cost := time.Since(start)
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		logger.Printf("httputil: %s %s status=%d cost=%s err=%v", req.Method, req.URL.String(), status, cost, err)
+		return resp, err
+	}
+}
+
+// MetricsCollector 抽象了 MetricsMiddleware 上报的三类指标,便于接入 Prometheus 等监控系统
+type MetricsCollector interface {
+	// InFlight 在请求开始/结束时分别传入 +1/-1
+	InFlight(delta int)
+	// ObserveLatency 上报一次请求耗时
+	ObserveLatency(method, host string, d time.Duration)
+	// IncStatus 按状态码(或 "error")计数
+	IncStatus(method, host string, status string)
+}
+
+// inMemoryMetrics 是不依赖外部监控系统的默认实现,可用于开发调试或简单场景
+type inMemoryMetrics struct {
+	mu        sync.Mutex
+	inFlight  int64
+	latencies []time.Duration
+	statusCnt map[string]int64
+}
+
+// NewInMemoryMetrics 创建一个进程内的 MetricsCollector,生产环境建议替换为 Prometheus 等实现
+func NewInMemoryMetrics() MetricsCollector {
+	return &inMemoryMetrics{statusCnt: make(map[string]int64)}
+}
+
+func (m *inMemoryMetrics) InFlight(delta int) {
+	atomic.AddInt64(&m.inFlight, int64(delta))
+}
+
+func (m *inMemoryMetrics) ObserveLatency(method, host string, d time.Duration) {
+	m.mu.Lock()
+	m.latencies = append(m.latencies, d)
+	m.mu.Unlock()
+}
+
+func (m *inMemoryMetrics) IncStatus(method, host string, status string) {
+	key := method + " " + host + " " + status
+	m.mu.Lock()
+	m.statusCnt[key]++
+	m.mu.Unlock()
+}
+
+// MetricsMiddleware 上报 in-flight 数量、延迟分布和状态码计数
+func MetricsMiddleware(collector MetricsCollector) Middleware {
+	return func(ctx context.Context, req *http.Request, next Handler) (*http.Response, error) {
+		collector.InFlight(1)
+		start := time.Now()
+		resp, err := next.Do(ctx, req)
+		collector.InFlight(-1)
+		collector.ObserveLatency(req.Method, req.URL.Host, time.Since(start))
+		status := "error"
+		if resp != nil {
+			status = fmt.Sprintf("%d", resp.StatusCode)
+		}
+		collector.IncStatus(req.Method, req.URL.Host, status)
+		return resp, err
+	}
+}
+
+// TracingMiddleware 按 W3C traceparent 规范注入追踪信息,若请求已带 traceparent 则透传 trace-id
+func TracingMiddleware() Middleware {
+	return func(ctx context.Context, req *http.Request, next Handler) (*http.Response, error) {
+		if req.Header.Get("traceparent") == "" {
+			traceId := randomHex(16)
+			spanId := randomHex(8)
+			req.Header.Set("traceparent", fmt.Sprintf("00-%s-%s-01", traceId, spanId))
+		}
+		return next.Do(ctx, req)
+	}
+}
+
+// RequestIDMiddleware 在缺省情况下为请求生成 X-Request-Id
+func RequestIDMiddleware() Middleware {
+	return func(ctx context.Context, req *http.Request, next Handler) (*http.Response, error) {
+		if req.Header.Get("X-Request-Id") == "" {
+			req.Header.Set("X-Request-Id", randomHex(16))
+		}
+		return next.Do(ctx, req)
+	}
+}
+
+// GzipMiddleware 透明解压 gzip/deflate 编码的响应体
+func GzipMiddleware() Middleware {
+	return func(ctx context.Context, req *http.Request, next Handler) (*http.Response, error) {
+		resp, err := next.Do(ctx, req)
+		if err != nil || resp == nil {
+			return resp, err
+		}
+		switch strings.ToLower(resp.Header.Get("Content-Encoding")) {
+		case "gzip":
+			gr, gerr := gzip.NewReader(resp.Body)
+			if gerr != nil {
+				// Content-Encoding 声明了 gzip 但 body 打不开(截断/损坏),必须把这个错误
+				// 报告给调用方,否则调用方会拿到一个 err==nil 但其实还是原始 gzip 字节的 body
+				return resp, fmt.Errorf("httputil: gzip decode failed: %w", gerr)
+			}
+			resp.Body = decompressedBody{decoder: gr, source: resp.Body}
+			resp.Header.Del("Content-Encoding")
+		case "deflate":
+			resp.Body = decompressedBody{decoder: flate.NewReader(resp.Body), source: resp.Body}
+			resp.Header.Del("Content-Encoding")
+		}
+		return resp, err
+	}
+}
+
+// decompressedBody 包装一个解压 reader 及其背后的原始响应体,Close 时两者都会被关闭
+// gzip.Reader.Close 和 flate 的 Close 都只负责释放解压器自身状态,不会关闭底层的 resp.Body,
+// 如果只关闭解压器,原始的 TCP/TLS 连接永远不会被释放
+type decompressedBody struct {
+	decoder io.ReadCloser
+	source  io.ReadCloser
+}
+
+func (b decompressedBody) Read(p []byte) (int, error) {
+	return b.decoder.Read(p)
+}
+
+func (b decompressedBody) Close() error {
+	err := b.decoder.Close()
+	if serr := b.source.Close(); err == nil {
+		err = serr
+	}
+	return err
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
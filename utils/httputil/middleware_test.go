@@ -0,0 +1,139 @@
+package httputil
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+// trackedCloser 记录自己是否被关闭,用于验证 GzipMiddleware 有没有释放原始响应体
+type trackedCloser struct {
+	io.Reader
+	closed bool
+}
+
+func (c *trackedCloser) Close() error {
+	c.closed = true
+	return nil
+}
+
+func gzipCompress(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func deflateCompress(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGzipMiddleware_ClosesUnderlyingBody(t *testing.T) {
+	payload := []byte("hello gzip world")
+	source := &trackedCloser{Reader: bytes.NewReader(gzipCompress(t, payload))}
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Encoding": []string{"gzip"}},
+		Body:       source,
+	}
+
+	mw := GzipMiddleware()
+	next := HandlerFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+		return resp, nil
+	})
+
+	got, err := mw(context.Background(), &http.Request{}, next)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body, err := ioutil.ReadAll(got.Body)
+	if err != nil {
+		t.Fatalf("read decompressed body: %v", err)
+	}
+	if string(body) != string(payload) {
+		t.Fatalf("decompressed body = %q, want %q", body, payload)
+	}
+	if err := got.Body.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if !source.closed {
+		t.Error("closing the decompressed body did not close the underlying response body")
+	}
+}
+
+func TestGzipMiddleware_CorruptBodyReturnsError(t *testing.T) {
+	source := &trackedCloser{Reader: bytes.NewReader([]byte("not actually gzip"))}
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Encoding": []string{"gzip"}},
+		Body:       source,
+	}
+
+	mw := GzipMiddleware()
+	next := HandlerFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+		return resp, nil
+	})
+
+	_, err := mw(context.Background(), &http.Request{}, next)
+	if err == nil {
+		t.Fatal("expected an error for a corrupt gzip body, got nil")
+	}
+}
+
+func TestDeflateMiddleware_ClosesUnderlyingBody(t *testing.T) {
+	payload := []byte("hello deflate world")
+	source := &trackedCloser{Reader: bytes.NewReader(deflateCompress(t, payload))}
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Encoding": []string{"deflate"}},
+		Body:       source,
+	}
+
+	mw := GzipMiddleware()
+	next := HandlerFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+		return resp, nil
+	})
+
+	got, err := mw(context.Background(), &http.Request{}, next)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body, err := ioutil.ReadAll(got.Body)
+	if err != nil {
+		t.Fatalf("read decompressed body: %v", err)
+	}
+	if string(body) != string(payload) {
+		t.Fatalf("decompressed body = %q, want %q", body, payload)
+	}
+	if err := got.Body.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if !source.closed {
+		t.Error("closing the decompressed body did not close the underlying response body")
+	}
+}
@@ -0,0 +1,139 @@
+package httputil
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileSource 描述一个待上传文件,Path/Bytes/Reader 三选一
+type FileSource struct {
+	Path        string    // 本地文件路径
+	Bytes       []byte    // 内存中的文件内容
+	Reader      io.Reader // 任意数据源,与 Bytes 二选一即可
+	FileName    string    // 可选,缺省时从 Path 推断
+	ContentType string    // 可选,缺省时该文件分片不带 Content-Type 头
+}
+
+// open 返回文件内容的 io.Reader 以及推断出的文件名
+func (fs FileSource) open() (io.Reader, string, error) {
+	name := fs.FileName
+	switch {
+	case fs.Reader != nil:
+		return fs.Reader, name, nil
+	case fs.Bytes != nil:
+		return bytesReader(fs.Bytes), name, nil
+	case fs.Path != "":
+		f, err := os.Open(fs.Path)
+		if err != nil {
+			return nil, "", err
+		}
+		if name == "" {
+			name = filepath.Base(fs.Path)
+		}
+		return f, name, nil
+	default:
+		return bytesReader(nil), name, nil
+	}
+}
+
+// NewMultipartRequest 构造 multipart/form-data 请求,文件内容通过 io.Pipe 边读边写,避免整体缓冲到内存
+// fields 为普通表单字段,files 为待上传文件,key 为表单字段名
+func NewMultipartRequest(url string, fields map[string]interface{}, files map[string]FileSource, args ...interface{}) (req *http.Request, err error) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	req, err = http.NewRequest("POST", url, pr)
+	if err != nil {
+		// 还没有人会去读 pr,这里不能启动写入 goroutine,否则它会在第一次 Write/Close 时永久阻塞
+		pr.Close()
+		pw.Close()
+		return
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if len(args) > 0 {
+		SetHeaders(req, args[0])
+	}
+
+	go func() {
+		writeErr := writeMultipartBody(mw, fields, files)
+		if writeErr != nil {
+			pw.CloseWithError(writeErr)
+			return
+		}
+		pw.CloseWithError(mw.Close())
+	}()
+
+	return
+}
+
+func writeMultipartBody(mw *multipart.Writer, fields map[string]interface{}, files map[string]FileSource) error {
+	for k, v := range fields {
+		if err := mw.WriteField(k, toString(v)); err != nil {
+			return err
+		}
+	}
+	for field, fs := range files {
+		reader, fileName, err := fs.open()
+		if err != nil {
+			return err
+		}
+		if closer, ok := reader.(io.Closer); ok {
+			defer closer.Close()
+		}
+
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", contentDisposition(field, fileName))
+		if fs.ContentType != "" {
+			h.Set("Content-Type", fs.ContentType)
+		}
+		part, err := mw.CreatePart(h)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, reader); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func bytesReader(b []byte) io.Reader {
+	return bytes.NewReader(b)
+}
+
+func toString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// quoteEscaper 和标准库 mime/multipart 的 quoteEscaper 一致:
+// quoted-string 里的 `\` 和 `"` 必须转义,否则拼出来的 header 无法被正确解析
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeDispositionValue 转义 quoted-string 中的 `\`/`"`,并去掉 CR/LF 防止 header 注入或截断
+func escapeDispositionValue(v string) string {
+	v = strings.ReplaceAll(v, "\r", "")
+	v = strings.ReplaceAll(v, "\n", "")
+	return quoteEscaper.Replace(v)
+}
+
+// contentDisposition 按 RFC 2231 编码含非 ASCII 字符的文件名,保证 UTF-8 文件名也能正确传输
+func contentDisposition(field, fileName string) string {
+	field = escapeDispositionValue(field)
+	if fileName == "" {
+		return fmt.Sprintf(`form-data; name="%s"`, field)
+	}
+	safeName := escapeDispositionValue(fileName)
+	return fmt.Sprintf(`form-data; name="%s"; filename="%s"; filename*=UTF-8''%s`,
+		field, safeName, strings.ReplaceAll(url.QueryEscape(fileName), "+", "%20"))
+}
@@ -0,0 +1,38 @@
+package httputil
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestContentDisposition_EscapesQuotesAndBackslashes(t *testing.T) {
+	got := contentDisposition(`field"na\me`, "")
+	want := `form-data; name="field\"na\\me"`
+	if got != want {
+		t.Fatalf("contentDisposition = %q, want %q", got, want)
+	}
+}
+
+func TestContentDisposition_StripsCRLF(t *testing.T) {
+	got := contentDisposition("field", "evil\r\nContent-Type: text/html\r\n.txt")
+	if strings.Contains(got, "\r") || strings.Contains(got, "\n") {
+		t.Fatalf("contentDisposition leaked CR/LF into header value: %q", got)
+	}
+}
+
+func TestNewMultipartRequest_InvalidURLDoesNotLeakGoroutine(t *testing.T) {
+	before := runtime.NumGoroutine()
+	req, err := NewMultipartRequest("://not-a-valid-url", nil, nil)
+	if err == nil {
+		t.Fatal("expected an error for an invalid URL")
+	}
+	if req != nil {
+		t.Fatalf("expected a nil request on error, got %v", req)
+	}
+	// 若实现先启动写入 goroutine 再调用 http.NewRequest,这里的 goroutine 会在
+	// pw.Write/pw.Close 上永久阻塞,调用返回后 goroutine 数量会比调用前多
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("goroutine count grew from %d to %d, the writer goroutine leaked", before, after)
+	}
+}
@@ -0,0 +1,207 @@
+package httputil
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	ContentTypeXML      = "application/xml"
+	ContentTypeYAML     = "application/x-yaml"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// ResponseError 包装非预期响应,携带状态码和响应体前缀,便于调试定位问题
+type ResponseError struct {
+	StatusCode int
+	BodyPrefix string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("httputil: unexpected status_code(%d) body(%s)", e.StatusCode, e.BodyPrefix)
+}
+
+// maxBodyPrefix 是 ResponseError 中保留的响应体截断长度
+const maxBodyPrefix = 512
+
+// Response 包装 *http.Response,提供按内容类型解码响应体的便捷方法
+type Response struct {
+	*http.Response
+}
+
+// WrapResponse 将标准库 *http.Response 包装为 Response
+func WrapResponse(resp *http.Response) *Response {
+	return &Response{Response: resp}
+}
+
+// readBody 读取并关闭响应体;读取失败时原样返回读取错误,状态码不在 2xx 范围时返回 ResponseError
+func (r *Response) readBody() (body []byte, err error) {
+	defer r.Body.Close()
+	body, err = ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if r.StatusCode < 200 || r.StatusCode >= 300 {
+		prefix := body
+		if len(prefix) > maxBodyPrefix {
+			prefix = prefix[:maxBodyPrefix]
+		}
+		return body, &ResponseError{StatusCode: r.StatusCode, BodyPrefix: string(prefix)}
+	}
+	return body, nil
+}
+
+// BindJSON 将响应体解码为 JSON 到 v
+func (r *Response) BindJSON(v interface{}) error {
+	body, err := r.readBody()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// BindXML 将响应体解码为 XML 到 v
+func (r *Response) BindXML(v interface{}) error {
+	body, err := r.readBody()
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(body, v)
+}
+
+// BindYAML 将响应体解码为 YAML 到 v
+func (r *Response) BindYAML(v interface{}) error {
+	body, err := r.readBody()
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(body, v)
+}
+
+// BindProtobuf 将响应体解码为 protobuf 到 v
+func (r *Response) BindProtobuf(v proto.Message) error {
+	body, err := r.readBody()
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(body, v)
+}
+
+// BindString 将响应体读取为字符串
+func (r *Response) BindString(v *string) error {
+	body, err := r.readBody()
+	if err != nil {
+		return err
+	}
+	*v = string(body)
+	return nil
+}
+
+// BindBytes 将响应体读取为 []byte
+func (r *Response) BindBytes(v *[]byte) error {
+	body, err := r.readBody()
+	if err != nil {
+		return err
+	}
+	*v = body
+	return nil
+}
+
+// BindStream 将响应体原样拷贝到 w,不在内存中缓冲,适合大文件下载
+func (r *Response) BindStream(w io.Writer) error {
+	defer r.Body.Close()
+	_, err := io.Copy(w, r.Body)
+	return err
+}
+
+// Bind 根据响应的 Content-Type 自动选择解码方式解码到 v
+func (r *Response) Bind(v interface{}) error {
+	ct := r.Header.Get("Content-Type")
+	switch {
+	case strings.Contains(ct, "json"):
+		return r.BindJSON(v)
+	case strings.Contains(ct, "xml"):
+		return r.BindXML(v)
+	case strings.Contains(ct, "yaml"):
+		return r.BindYAML(v)
+	case strings.Contains(ct, "protobuf"):
+		msg, ok := v.(proto.Message)
+		if !ok {
+			return fmt.Errorf("httputil: Bind content-type(%s) requires a proto.Message", ct)
+		}
+		return r.BindProtobuf(msg)
+	default:
+		return r.BindJSON(v)
+	}
+}
+
+//XML POST Request对象
+func NewXmlPostRequest(url string, v interface{}, args ...interface{}) (req *http.Request, err error) {
+	var buf []byte
+	if v != nil {
+		buf, err = xml.Marshal(v)
+		if err != nil {
+			return
+		}
+	}
+
+	req, err = http.NewRequest("POST", url, strings.NewReader(string(buf)))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", ContentTypeXML)
+	if len(args) > 0 {
+		SetHeaders(req, args[0])
+	}
+	return
+}
+
+//YAML POST Request对象
+func NewYamlPostRequest(url string, v interface{}, args ...interface{}) (req *http.Request, err error) {
+	var buf []byte
+	if v != nil {
+		buf, err = yaml.Marshal(v)
+		if err != nil {
+			return
+		}
+	}
+
+	req, err = http.NewRequest("POST", url, strings.NewReader(string(buf)))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", ContentTypeYAML)
+	if len(args) > 0 {
+		SetHeaders(req, args[0])
+	}
+	return
+}
+
+//Protobuf POST Request对象
+func NewProtobufPostRequest(url string, msg proto.Message, args ...interface{}) (req *http.Request, err error) {
+	var buf []byte
+	if msg != nil {
+		buf, err = proto.Marshal(msg)
+		if err != nil {
+			return
+		}
+	}
+
+	req, err = http.NewRequest("POST", url, strings.NewReader(string(buf)))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", ContentTypeProtobuf)
+	if len(args) > 0 {
+		SetHeaders(req, args[0])
+	}
+	return
+}
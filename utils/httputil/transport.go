@@ -0,0 +1,183 @@
+package httputil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/crypto/pkcs12"
+	"golang.org/x/net/proxy"
+)
+
+// WithTimeout 设置请求超时,替代旧版 NewClient(timeout) 的第一个参数
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *myClient) {
+		c.timeout = timeout
+	}
+}
+
+// WithProxy 设置 HTTP/HTTPS/SOCKS5 代理,rawUrl 形如 http://host:port 或 socks5://host:port
+func WithProxy(rawUrl string) Option {
+	return func(c *myClient) {
+		u, err := url.Parse(rawUrl)
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.proxyURL = u
+	}
+}
+
+// WithTLSConfig 直接指定底层 *tls.Config,会与其它 TLS 相关 Option 叠加
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *myClient) {
+		c.tlsConfig = cfg
+	}
+}
+
+// WithClientCert 使用 PEM 编码的证书/私钥配置双向 TLS 客户端证书
+func WithClientCert(certPEM, keyPEM []byte) Option {
+	return func(c *myClient) {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.clientCerts = append(c.clientCerts, cert)
+	}
+}
+
+// WithP12Cert 从 PKCS12(.p12/.pfx)文件加载客户端证书,用于双向 TLS
+func WithP12Cert(path, password string) Option {
+	return func(c *myClient) {
+		pfxData, err := ioutil.ReadFile(path)
+		if err != nil {
+			c.err = err
+			return
+		}
+		key, cert, err := pkcs12.Decode(pfxData, password)
+		if err != nil {
+			c.err = err
+			return
+		}
+		tlsCert := tls.Certificate{
+			Certificate: [][]byte{cert.Raw},
+			PrivateKey:  key,
+			Leaf:        cert,
+		}
+		c.clientCerts = append(c.clientCerts, tlsCert)
+	}
+}
+
+// WithInsecureSkipVerify 跳过服务端证书校验,仅用于测试/内网自签场景
+func WithInsecureSkipVerify(skip bool) Option {
+	return func(c *myClient) {
+		c.insecureSkipVerify = skip
+	}
+}
+
+// WithRootCAs 指定受信任的根证书(PEM 编码),用于校验私有 CA 签发的服务端证书
+// 设置后只信任这里给出的证书,系统根证书和 WithTLSConfig 中的 RootCAs 不再参与校验;多次调用以最后一次为准
+func WithRootCAs(pemCerts [][]byte) Option {
+	return func(c *myClient) {
+		pool := x509.NewCertPool()
+		for _, pemCert := range pemCerts {
+			if !pool.AppendCertsFromPEM(pemCert) {
+				c.err = fmt.Errorf("httputil: failed to parse root CA cert")
+				return
+			}
+		}
+		c.rootCAs = pool
+	}
+}
+
+// WithTransport 直接指定底层 *http.Transport,此时其它传输层 Option(代理、TLS、连接池参数)将被忽略
+func WithTransport(transport *http.Transport) Option {
+	return func(c *myClient) {
+		c.transport = transport
+	}
+}
+
+// WithCookieJar 为客户端配置 cookie jar,使多次请求间可以保持会话
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(c *myClient) {
+		c.cookieJar = jar
+	}
+}
+
+// WithMaxIdleConnsPerHost 调整每个 host 的最大空闲连接数,默认沿用 http.DefaultTransport 的值
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(c *myClient) {
+		c.maxIdleConnsPerHost = n
+	}
+}
+
+// WithIdleConnTimeout 调整空闲连接的存活时间
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(c *myClient) {
+		c.idleConnTimeout = d
+	}
+}
+
+// WithDisableKeepAlives 关闭连接复用,每次请求都新建连接
+func WithDisableKeepAlives(disable bool) Option {
+	return func(c *myClient) {
+		c.disableKeepAlives = disable
+	}
+}
+
+// buildTransport 根据配置构建一个可复用的 *http.Transport,供高并发场景下的单个 Client 共享使用
+func buildTransport(c *myClient) (http.RoundTripper, error) {
+	if c.transport != nil {
+		return c.transport, nil
+	}
+
+	base, _ := http.DefaultTransport.(*http.Transport)
+	t := base.Clone()
+
+	tlsConfig := c.tlsConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	} else {
+		tlsConfig = tlsConfig.Clone()
+	}
+	if c.insecureSkipVerify {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	if c.rootCAs != nil {
+		tlsConfig.RootCAs = c.rootCAs
+	}
+	if len(c.clientCerts) > 0 {
+		tlsConfig.Certificates = append(tlsConfig.Certificates, c.clientCerts...)
+	}
+	t.TLSClientConfig = tlsConfig
+
+	if c.maxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = c.maxIdleConnsPerHost
+	}
+	if c.idleConnTimeout > 0 {
+		t.IdleConnTimeout = c.idleConnTimeout
+	}
+	if c.disableKeepAlives {
+		t.DisableKeepAlives = true
+	}
+
+	if c.proxyURL != nil {
+		if c.proxyURL.Scheme == "socks5" || c.proxyURL.Scheme == "socks5h" {
+			dialer, err := proxy.FromURL(c.proxyURL, proxy.Direct)
+			if err != nil {
+				return nil, err
+			}
+			t.DialContext = nil
+			t.Dial = dialer.Dial
+		} else {
+			t.Proxy = http.ProxyURL(c.proxyURL)
+		}
+	}
+
+	return t, nil
+}